@@ -0,0 +1,84 @@
+package recognizer
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestJPEG writes a minimal solid-color JPEG to dir and returns its
+// path. It exists purely to give RecognizeMultiples something it can
+// decode a header from; no dlib/native recognizer is involved.
+func writeTestJPEG(t *testing.T, dir string, name string, size int) string {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := jpeg.Encode(f, img, nil); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+
+	return path
+}
+
+// TestClassifyBatchRejectsEveryPathBelowMinImageSize exercises
+// ClassifyBatch's worker-pool fan-out without needing a live
+// goFace.Recognizer: every image is smaller than minImageSize, so
+// checkMinImageSize rejects each one before RecognizeMultiples ever
+// reaches the native recognizer, yet the worker pool still has to fan
+// out, preserve per-path results, and join cleanly.
+func TestClassifyBatchRejectsEveryPathBelowMinImageSize(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{
+		writeTestJPEG(t, dir, "a.jpg", 4),
+		writeTestJPEG(t, dir, "b.jpg", 4),
+		writeTestJPEG(t, dir, "c.jpg", 4),
+		writeTestJPEG(t, dir, "d.jpg", 4),
+	}
+
+	r := &Recognizer{minImageSize: 1024}
+
+	results := r.ClassifyBatch(paths, 3)
+
+	if len(results) != len(paths) {
+		t.Fatalf("got %d results, want %d", len(results), len(paths))
+	}
+	for i, res := range results {
+		if res.Path != paths[i] {
+			t.Fatalf("results[%d].Path = %q, want %q (worker pool lost ordering)", i, res.Path, paths[i])
+		}
+		if res.Err == nil {
+			t.Fatalf("results[%d].Err = nil, want a min-image-size error", i)
+		}
+		if res.Faces != nil {
+			t.Fatalf("results[%d].Faces = %v, want nil on error", i, res.Faces)
+		}
+	}
+}
+
+func TestClassifyBatchZeroConcurrencyFallsBackToOne(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{writeTestJPEG(t, dir, "a.jpg", 4)}
+
+	r := &Recognizer{minImageSize: 1024}
+
+	results := r.ClassifyBatch(paths, 0)
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("ClassifyBatch with concurrency=0 = %+v, want one errored result", results)
+	}
+}
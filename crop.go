@@ -0,0 +1,108 @@
+package recognizer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+
+	goFace "github.com/Kagami/go-face"
+)
+
+/*
+Crop decodes the image at path, detects every face on it and returns one
+sub-image per face, each expanded by padding (a fraction of the face
+rectangle's width/height) and clamped to the source image bounds.
+*/
+func (_this *Recognizer) Crop(path string, padding float64) ([]image.Image, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	faces, err := _this.RecognizeMultiples(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't recognize: %v", err)
+	}
+
+	return CropFromImage(img, faces, padding), nil
+}
+
+/*
+CropFromImage expands each face's Rectangle by padding (a fraction of its
+width/height, clamped to img's bounds) and returns the corresponding
+sub-images.
+*/
+func CropFromImage(img image.Image, faces []goFace.Face, padding float64) []image.Image {
+
+	crops := make([]image.Image, 0, len(faces))
+
+	for _, face := range faces {
+		crops = append(crops, cropRect(img, paddedRect(face.Rectangle, padding, img.Bounds())))
+	}
+
+	return crops
+}
+
+func paddedRect(r image.Rectangle, padding float64, bounds image.Rectangle) image.Rectangle {
+	dx := int(float64(r.Dx()) * padding)
+	dy := int(float64(r.Dy()) * padding)
+
+	padded := image.Rect(r.Min.X-dx, r.Min.Y-dy, r.Max.X+dx, r.Max.Y+dy)
+
+	return padded.Intersect(bounds)
+}
+
+func cropRect(img image.Image, r image.Rectangle) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+/*
+CropAndSave crops every face found in the image at path (see Crop) and
+writes each one to outDir as "<id>_<index>.jpg", returning the paths it
+wrote.
+*/
+func (_this *Recognizer) CropAndSave(path string, outDir string, padding float64) ([]string, error) {
+
+	crops, err := _this.Crop(path, padding)
+	if err != nil {
+		return nil, err
+	}
+
+	id := filepath.Base(path)
+	if ext := filepath.Ext(id); ext != "" {
+		id = id[:len(id)-len(ext)]
+	}
+
+	paths := make([]string, 0, len(crops))
+
+	for i, crop := range crops {
+		outPath := filepath.Join(outDir, fmt.Sprintf("%s_%d.jpg", id, i))
+
+		out, err := os.Create(outPath)
+		if err != nil {
+			return paths, err
+		}
+
+		err = jpeg.Encode(out, crop, nil)
+		out.Close()
+		if err != nil {
+			return paths, err
+		}
+
+		paths = append(paths, outPath)
+	}
+
+	return paths, nil
+}
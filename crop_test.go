@@ -0,0 +1,61 @@
+package recognizer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	goFace "github.com/Kagami/go-face"
+)
+
+func TestCropFromImageExtractsPaddedSubImages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	faces := []goFace.Face{
+		{Rectangle: image.Rect(40, 40, 60, 60)},
+	}
+
+	crops := CropFromImage(img, faces, 0.5)
+	if len(crops) != 1 {
+		t.Fatalf("got %d crops, want 1", len(crops))
+	}
+
+	// Rectangle is 20x20; 0.5 padding adds 10px on each side -> 40x40.
+	b := crops[0].Bounds()
+	if b.Dx() != 40 || b.Dy() != 40 {
+		t.Fatalf("crop bounds = %v, want 40x40", b)
+	}
+}
+
+func TestCropFromImageClampsPaddingToImageBounds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 30, 30))
+
+	faces := []goFace.Face{
+		{Rectangle: image.Rect(0, 0, 10, 10)},
+	}
+
+	// Padding large enough to push the padded rectangle outside the
+	// source image on every side.
+	crops := CropFromImage(img, faces, 5.0)
+	if len(crops) != 1 {
+		t.Fatalf("got %d crops, want 1", len(crops))
+	}
+
+	b := crops[0].Bounds()
+	if b.Dx() > 30 || b.Dy() > 30 {
+		t.Fatalf("crop bounds = %v, should be clamped to the 30x30 source image", b)
+	}
+}
+
+func TestCropFromImageNoFaces(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	crops := CropFromImage(img, nil, 0.2)
+	if len(crops) != 0 {
+		t.Fatalf("got %d crops, want 0", len(crops))
+	}
+}
@@ -0,0 +1,104 @@
+package recognizer
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	goFace "github.com/Kagami/go-face"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// descriptorBlob adapts a 128-float32 descriptor to gorm/database-sql's
+// Valuer/Scanner interfaces, serializing it as a single little-endian
+// byte blob. A bare [128]float32 column doesn't do this: database/sql
+// drivers don't know how to turn a Go array into a single blob value on
+// their own, and without this some drivers (sqlite among them) instead
+// try to bind it as a row-value tuple and reject the insert.
+type descriptorBlob [128]float32
+
+func (d descriptorBlob) Value() (driver.Value, error) {
+	buf := make([]byte, len(d)*4)
+	for i, f := range d {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(f))
+	}
+	return buf, nil
+}
+
+func (d *descriptorBlob) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("descriptorBlob: unsupported Scan type %T", value)
+	}
+	if len(b) != len(d)*4 {
+		return fmt.Errorf("descriptorBlob: blob has %d bytes, want %d", len(b), len(d)*4)
+	}
+	for i := range d {
+		d[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return nil
+}
+
+// gormFace is the row shape GormStore persists: the descriptor is stored
+// as a flat blob column via descriptorBlob, the pattern database-backed
+// photo managers built on go-face use instead of 128 separate float
+// columns.
+type gormFace struct {
+	ID         string         `gorm:"primaryKey"`
+	Descriptor descriptorBlob `gorm:"type:blob"`
+}
+
+func (gormFace) TableName() string { return "recognizer_faces" }
+
+// GormStore is a Store backed by any database gorm.io/gorm supports.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore migrates the backing table if needed and returns a Store
+// over db.
+func NewGormStore(db *gorm.DB) (*GormStore, error) {
+	if err := db.AutoMigrate(&gormFace{}); err != nil {
+		return nil, err
+	}
+	return &GormStore{db: db}, nil
+}
+
+func (_this *GormStore) Load() ([]Data, error) {
+	var rows []gormFace
+	if err := _this.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	data := make([]Data, len(rows))
+	for i, r := range rows {
+		data[i] = Data{Id: r.ID, Descriptor: goFace.Descriptor(r.Descriptor)}
+	}
+	return data, nil
+}
+
+func (_this *GormStore) Save(data []Data) error {
+	return _this.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&gormFace{}).Error; err != nil {
+			return err
+		}
+		for _, d := range data {
+			row := gormFace{ID: d.Id, Descriptor: descriptorBlob(d.Descriptor)}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (_this *GormStore) Upsert(d Data) error {
+	row := gormFace{ID: d.Id, Descriptor: descriptorBlob(d.Descriptor)}
+	return _this.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+}
+
+func (_this *GormStore) Delete(id string) error {
+	return _this.db.Delete(&gormFace{ID: id}).Error
+}
@@ -0,0 +1,36 @@
+package recognizer
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestGormStore(t *testing.T) *GormStore {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	// Every connection to ":memory:" opens its own empty database, so keep
+	// the pool to one connection: the one AutoMigrate creates the table on.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	store, err := NewGormStore(db)
+	if err != nil {
+		t.Fatalf("NewGormStore: %v", err)
+	}
+	return store
+}
+
+func TestGormStore(t *testing.T) {
+	runStoreContract(t, func() Store { return newTestGormStore(t) })
+}
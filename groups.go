@@ -0,0 +1,491 @@
+package recognizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	goFace "github.com/Kagami/go-face"
+)
+
+// GroupID identifies a cluster of dataset entries believed to belong to
+// the same person, the way photo-management tools built on go-face group
+// faces into "persons" rather than tracking every enrolled image on its
+// own.
+type GroupID int64
+
+// chineseWhispersRounds is the default number of label-propagation
+// iterations ClusterDataset runs before giving up on convergence.
+const chineseWhispersRounds = 20
+
+// chineseWhispersConvergence stops propagation early once fewer than
+// this fraction of nodes change label in a round.
+const chineseWhispersConvergence = 0.01
+
+// defaultGroupEdgeThreshold mirrors the default Tolerance: descriptors
+// closer than this Euclidean distance are considered an edge in the
+// k-NN graph used for clustering.
+const defaultGroupEdgeThreshold = 0.4
+
+// groupIndex holds the clustering state for a Recognizer's Dataset: one
+// label per dataset index, plus the reverse GroupID -> []DatasetIndex
+// map and any human-assigned names.
+type groupIndex struct {
+	edgeThreshold float32
+	labels        []GroupID
+	names         map[GroupID]string
+	nextID        GroupID
+}
+
+func newGroupIndex(labels []GroupID, edgeThreshold float32) *groupIndex {
+	g := &groupIndex{
+		edgeThreshold: edgeThreshold,
+		labels:        labels,
+		names:         make(map[GroupID]string),
+	}
+	for _, l := range labels {
+		if l >= g.nextID {
+			g.nextID = l + 1
+		}
+	}
+	return g
+}
+
+// removeIndex drops the label for dataset index removed and shifts every
+// label after it down by one, keeping labels aligned with Dataset after
+// RemoveFromDataset has spliced an entry out.
+func (g *groupIndex) removeIndex(removed int) {
+	if removed >= len(g.labels) {
+		return
+	}
+	g.labels = append(g.labels[:removed], g.labels[removed+1:]...)
+}
+
+// growTo extends labels, if it's shorter than n, by giving every new
+// index its own fresh GroupID. Used both by onDatasetGrew (growing by
+// one) and ClusterDataset (catching up on however many entries Dataset
+// gained while clustering ran unlocked).
+func (g *groupIndex) growTo(n int) {
+	for len(g.labels) < n {
+		g.labels = append(g.labels, g.nextID)
+		g.nextID++
+	}
+}
+
+func (g *groupIndex) members() map[GroupID][]int {
+	out := make(map[GroupID][]int)
+	for i, l := range g.labels {
+		out[l] = append(out[l], i)
+	}
+	return out
+}
+
+// GroupEdgeThreshold returns the Euclidean-distance cutoff used to build
+// the k-NN graph during clustering. Until ClusterDataset has run it is
+// Tolerance (or defaultGroupEdgeThreshold if Tolerance is not positive);
+// after that it is the value the first ClusterDataset used, and later
+// changes to Tolerance do not affect it.
+func (_this *Recognizer) GroupEdgeThreshold() float32 {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+	return _this.groupEdgeThresholdLocked()
+}
+
+// groupEdgeThresholdLocked is GroupEdgeThreshold's body, for callers that
+// already hold mu (as a reader or writer) and must not recurse into
+// RLock.
+func (_this *Recognizer) groupEdgeThresholdLocked() float32 {
+	if _this.groups != nil {
+		return _this.groups.edgeThreshold
+	}
+	if _this.Tolerance > 0 {
+		return _this.Tolerance
+	}
+	return defaultGroupEdgeThreshold
+}
+
+/*
+ClusterDataset (re)builds face groups from scratch: it constructs a k-NN
+graph over every descriptor in Dataset using Euclidean distance with
+GroupEdgeThreshold as the edge cutoff, then runs Chinese Whispers label
+propagation (each node starts with its own label and repeatedly adopts
+the highest-weighted label among its neighbors, weight = 1/(1+distance))
+until fewer than 1% of labels change in a round or chineseWhispersRounds
+is reached.
+*/
+func (_this *Recognizer) ClusterDataset() error {
+
+	_this.mu.RLock()
+	n := len(_this.Dataset)
+	descriptors := make([]goFace.Descriptor, n)
+	for i, d := range _this.Dataset {
+		descriptors[i] = d.Descriptor
+	}
+	threshold := _this.groupEdgeThresholdLocked()
+	_this.mu.RUnlock()
+
+	labels := runChineseWhispers(descriptors, threshold)
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	groups := newGroupIndex(labels, threshold)
+
+	// Dataset may have grown while Chinese Whispers was running unlocked
+	// above (a concurrent AddSingleData/AddImageToDataset's onDatasetGrew
+	// would have seen _this.groups still nil at that point and no-opped,
+	// see onDatasetGrew). Give any such late arrivals their own fresh
+	// label now rather than leaving them permanently unlabeled.
+	groups.growTo(len(_this.Dataset))
+
+	_this.groups = groups
+
+	return nil
+}
+
+func runChineseWhispers(descriptors []goFace.Descriptor, threshold float32) []GroupID {
+
+	n := len(descriptors)
+	labels := make([]GroupID, n)
+	for i := range labels {
+		labels[i] = GroupID(i)
+	}
+	if n < 2 {
+		return labels
+	}
+
+	neighbors := buildKNNGraph(descriptors, threshold)
+
+	for round := 0; round < chineseWhispersRounds; round++ {
+		order := rand.Perm(n)
+		changed := 0
+
+		for _, idx := range order {
+			if len(neighbors[idx]) == 0 {
+				continue
+			}
+
+			weights := make(map[GroupID]float64)
+			for _, e := range neighbors[idx] {
+				weights[labels[e.index]] += float64(1 / (1 + e.distance))
+			}
+
+			best, bestWeight := labels[idx], -1.0
+			for label, w := range weights {
+				if w > bestWeight {
+					best, bestWeight = label, w
+				}
+			}
+
+			if best != labels[idx] {
+				labels[idx] = best
+				changed++
+			}
+		}
+
+		if float64(changed)/float64(n) < chineseWhispersConvergence {
+			break
+		}
+	}
+
+	return labels
+}
+
+type graphEdge struct {
+	index    int
+	distance float32
+}
+
+// buildKNNGraph links every pair of descriptors whose Euclidean distance
+// is within threshold. Dataset sizes handled by this recognizer are small
+// enough (enrolled faces, not a web-scale index) that an all-pairs scan
+// is simpler and plenty fast; it's the neighbor list, not the search
+// structure, that Chinese Whispers actually needs.
+func buildKNNGraph(descriptors []goFace.Descriptor, threshold float32) [][]graphEdge {
+	n := len(descriptors)
+	neighbors := make([][]graphEdge, n)
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := euclideanDistance(descriptors[i], descriptors[j])
+			if d <= threshold {
+				neighbors[i] = append(neighbors[i], graphEdge{index: j, distance: d})
+				neighbors[j] = append(neighbors[j], graphEdge{index: i, distance: d})
+			}
+		}
+	}
+
+	return neighbors
+}
+
+func euclideanDistance(a, b goFace.Descriptor) float32 {
+	var sum float64
+	for i := range a {
+		diff := float64(a[i] - b[i])
+		sum += diff * diff
+	}
+	return float32(math.Sqrt(sum))
+}
+
+// onDatasetGrew is called after a new entry lands at newIndex in
+// Dataset. If the dataset hasn't been clustered yet there's nothing to
+// update incrementally - the next ClusterDataset call will pick it up.
+// Otherwise it only recomputes edges for the new node and lets it (and
+// its immediate neighborhood) re-settle, rather than re-running
+// propagation over the whole graph.
+func (_this *Recognizer) onDatasetGrew(newIndex int) {
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	if _this.groups == nil {
+		return
+	}
+
+	descriptors := make([]goFace.Descriptor, len(_this.Dataset))
+	for i, d := range _this.Dataset {
+		descriptors[i] = d.Descriptor
+	}
+
+	_this.groups.growTo(newIndex + 1)
+
+	neighbors := make([]graphEdge, 0)
+	for i, d := range descriptors {
+		if i == newIndex {
+			continue
+		}
+		dist := euclideanDistance(descriptors[newIndex], d)
+		if dist <= _this.groups.edgeThreshold {
+			neighbors = append(neighbors, graphEdge{index: i, distance: dist})
+		}
+	}
+
+	if len(neighbors) == 0 {
+		return
+	}
+
+	affected := append([]int{newIndex}, edgeIndices(neighbors)...)
+	for round := 0; round < chineseWhispersRounds; round++ {
+		changed := 0
+		for _, idx := range affected {
+			local := make([]graphEdge, 0)
+			for i, d := range descriptors {
+				if i == idx {
+					continue
+				}
+				dist := euclideanDistance(descriptors[idx], d)
+				if dist <= _this.groups.edgeThreshold {
+					local = append(local, graphEdge{index: i, distance: dist})
+				}
+			}
+			if len(local) == 0 {
+				continue
+			}
+
+			weights := make(map[GroupID]float64)
+			for _, e := range local {
+				weights[_this.groups.labels[e.index]] += float64(1 / (1 + e.distance))
+			}
+			best, bestWeight := _this.groups.labels[idx], -1.0
+			for label, w := range weights {
+				if w > bestWeight {
+					best, bestWeight = label, w
+				}
+			}
+			if best != _this.groups.labels[idx] {
+				_this.groups.labels[idx] = best
+				changed++
+			}
+		}
+		if changed == 0 {
+			break
+		}
+	}
+}
+
+func edgeIndices(edges []graphEdge) []int {
+	out := make([]int, len(edges))
+	for i, e := range edges {
+		out[i] = e.index
+	}
+	return out
+}
+
+// Groups returns the current GroupID -> []DatasetIndex membership.
+// Returns nil until ClusterDataset has run at least once.
+func (_this *Recognizer) Groups() map[GroupID][]int {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+	if _this.groups == nil {
+		return nil
+	}
+	return _this.groups.members()
+}
+
+// GroupName returns the human-assigned name for id, if RenameGroup has
+// been called for it.
+func (_this *Recognizer) GroupName(id GroupID) (string, bool) {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+	if _this.groups == nil {
+		return "", false
+	}
+	name, ok := _this.groups.names[id]
+	return name, ok
+}
+
+// groupForIndex returns the GroupID assigned to dataset index idx by the
+// last ClusterDataset/AssignToGroup call. ok is false if the dataset
+// hasn't been clustered yet or idx is out of range. Callers must already
+// hold mu.
+func (_this *Recognizer) groupForIndex(idx int) (GroupID, bool) {
+	if _this.groups == nil || idx < 0 || idx >= len(_this.groups.labels) {
+		return 0, false
+	}
+	return _this.groups.labels[idx], true
+}
+
+/*
+ClassifyGroup is like Classify but returns the clustered GroupID for the
+matched face instead of its raw Dataset entry. ClusterDataset must have
+been called at least once, or this returns an error.
+*/
+func (_this *Recognizer) ClassifyGroup(path string) (GroupID, error) {
+	face, err := _this.RecognizeSingle(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return _this.classifyFaceGroup(face)
+}
+
+func (_this *Recognizer) classifyFaceGroup(face goFace.Face) (GroupID, error) {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+
+	personID := _this.rec.ClassifyThreshold(face.Descriptor, _this.Tolerance)
+	if personID < 0 {
+		return 0, fmt.Errorf("can't classify")
+	}
+
+	group, ok := _this.groupForIndex(personID)
+	if !ok {
+		return 0, errors.New("dataset has not been clustered yet, call ClusterDataset first")
+	}
+
+	return group, nil
+}
+
+/*
+ClassifyMultiplesGroup is like ClassifyMultiples but returns the
+clustered GroupID for each matched face instead of its raw Dataset entry.
+Faces that don't match any known group (clustering not run yet, or no
+match) are omitted, same as ClassifyMultiples does for unmatched faces.
+*/
+func (_this *Recognizer) ClassifyMultiplesGroup(path string) ([]GroupID, error) {
+	faces, err := _this.RecognizeMultiples(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't recognize: %v", err)
+	}
+
+	return _this.classifyFacesGroup(faces), nil
+}
+
+func (_this *Recognizer) classifyFacesGroup(faces []goFace.Face) []GroupID {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+
+	groupsRec := make([]GroupID, 0, len(faces))
+
+	for _, f := range faces {
+		personID := _this.rec.ClassifyThreshold(f.Descriptor, _this.Tolerance)
+		if personID < 0 {
+			continue
+		}
+		if group, ok := _this.groupForIndex(personID); ok {
+			groupsRec = append(groupsRec, group)
+		}
+	}
+
+	return groupsRec
+}
+
+/*
+AssignToGroup manually places the dataset entry whose descriptor matches
+descriptor into groupID, overriding whatever ClusterDataset decided for
+it. descriptor is matched against Dataset by nearest Euclidean distance;
+unlike Classify, no Tolerance cutoff is applied, so the nearest entry is
+taken however far away it is.
+*/
+func (_this *Recognizer) AssignToGroup(descriptor goFace.Descriptor, groupID GroupID) error {
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	if _this.groups == nil {
+		return errors.New("dataset has not been clustered yet, call ClusterDataset first")
+	}
+
+	best, bestDist := -1, float32(math.MaxFloat32)
+	for i, d := range _this.Dataset {
+		dist := euclideanDistance(descriptor, d.Descriptor)
+		if dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+
+	if best < 0 {
+		return errors.New("no matching face found in dataset")
+	}
+
+	_this.groups.growTo(best + 1)
+
+	_this.groups.labels[best] = groupID
+	if groupID >= _this.groups.nextID {
+		_this.groups.nextID = groupID + 1
+	}
+
+	return nil
+}
+
+/*
+MergeGroups folds b into a: every dataset entry labeled b is relabeled a,
+and b's name (if any) is dropped. Returns a for convenience.
+*/
+func (_this *Recognizer) MergeGroups(a, b GroupID) (GroupID, error) {
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	if _this.groups == nil {
+		return 0, errors.New("dataset has not been clustered yet, call ClusterDataset first")
+	}
+
+	for i, label := range _this.groups.labels {
+		if label == b {
+			_this.groups.labels[i] = a
+		}
+	}
+	delete(_this.groups.names, b)
+
+	return a, nil
+}
+
+/*
+RenameGroup attaches a human-readable name to a group id, e.g. turning
+the numeric label Chinese Whispers produced into "Alice".
+*/
+func (_this *Recognizer) RenameGroup(id GroupID, name string) error {
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	if _this.groups == nil {
+		return errors.New("dataset has not been clustered yet, call ClusterDataset first")
+	}
+
+	_this.groups.names[id] = name
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package recognizer
+
+import (
+	"sync"
+	"testing"
+
+	goFace "github.com/Kagami/go-face"
+)
+
+func descriptorAt(v float32) goFace.Descriptor {
+	var d goFace.Descriptor
+	for i := range d {
+		d[i] = v
+	}
+	return d
+}
+
+func newTestRecognizer(descriptors ...float32) *Recognizer {
+	r := &Recognizer{Tolerance: defaultGroupEdgeThreshold}
+	for i, v := range descriptors {
+		r.Dataset = append(r.Dataset, Data{Id: string(rune('a' + i)), Descriptor: descriptorAt(v)})
+	}
+	return r
+}
+
+func TestClusterDatasetGroupsNearbyDescriptorsTogether(t *testing.T) {
+	// Two tight clusters around 0.0 and 5.0, far enough apart (> the
+	// default 0.4 edge threshold) that Chinese Whispers should never
+	// draw an edge between them.
+	r := newTestRecognizer(0.0, 0.01, 0.02, 5.0, 5.01, 5.02)
+
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+
+	groups := r.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %v", len(groups), groups)
+	}
+
+	var sizes []int
+	for _, members := range groups {
+		sizes = append(sizes, len(members))
+	}
+	if !(sizes[0] == 3 && sizes[1] == 3) {
+		t.Fatalf("expected two groups of 3, got sizes %v", sizes)
+	}
+}
+
+func TestClusterDatasetSingletonsStayApart(t *testing.T) {
+	r := newTestRecognizer(0.0, 10.0, 20.0)
+
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+
+	groups := r.Groups()
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 singleton groups, got %d: %v", len(groups), groups)
+	}
+}
+
+func TestOnDatasetGrewFoldsNewEntryIntoExistingGroup(t *testing.T) {
+	r := newTestRecognizer(0.0, 0.01, 5.0)
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+
+	before := r.Groups()
+	if len(before) != 2 {
+		t.Fatalf("expected 2 groups before growth, got %d", len(before))
+	}
+
+	r.Dataset = append(r.Dataset, Data{Id: "new", Descriptor: descriptorAt(0.015)})
+	r.onDatasetGrew(len(r.Dataset) - 1)
+
+	after := r.Groups()
+	if len(after) != 2 {
+		t.Fatalf("expected the new entry to join an existing group, got %d groups", len(after))
+	}
+
+	newIndex := len(r.Dataset) - 1
+	newLabel, ok := r.groupForIndex(newIndex)
+	if !ok {
+		t.Fatalf("new entry has no group label")
+	}
+	firstLabel, ok := r.groupForIndex(0)
+	if !ok {
+		t.Fatalf("entry 0 has no group label")
+	}
+	if newLabel != firstLabel {
+		t.Fatalf("expected new entry to join group %v, got %v", firstLabel, newLabel)
+	}
+}
+
+func TestAssignToGroupOverridesClustering(t *testing.T) {
+	r := newTestRecognizer(0.0, 5.0)
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+
+	groupOfSecond, ok := r.groupForIndex(1)
+	if !ok {
+		t.Fatalf("entry 1 has no group label")
+	}
+
+	if err := r.AssignToGroup(descriptorAt(0.0), groupOfSecond); err != nil {
+		t.Fatalf("AssignToGroup: %v", err)
+	}
+
+	moved, ok := r.groupForIndex(0)
+	if !ok || moved != groupOfSecond {
+		t.Fatalf("expected entry 0 to be reassigned to group %v, got %v (ok=%v)", groupOfSecond, moved, ok)
+	}
+}
+
+func TestMergeGroupsFoldsBIntoA(t *testing.T) {
+	r := newTestRecognizer(0.0, 5.0)
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+
+	a, _ := r.groupForIndex(0)
+	b, _ := r.groupForIndex(1)
+
+	got, err := r.MergeGroups(a, b)
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	if got != a {
+		t.Fatalf("MergeGroups returned %v, want %v", got, a)
+	}
+
+	for i := range r.Dataset {
+		label, ok := r.groupForIndex(i)
+		if !ok || label != a {
+			t.Fatalf("entry %d still in group %v after merge, want %v", i, label, a)
+		}
+	}
+}
+
+func TestRenameGroupRequiresClustering(t *testing.T) {
+	r := newTestRecognizer(0.0)
+	if err := r.RenameGroup(GroupID(0), "Alice"); err == nil {
+		t.Fatalf("expected RenameGroup to fail before ClusterDataset")
+	}
+
+	if err := r.ClusterDataset(); err != nil {
+		t.Fatalf("ClusterDataset: %v", err)
+	}
+	label, _ := r.groupForIndex(0)
+	if err := r.RenameGroup(label, "Alice"); err != nil {
+		t.Fatalf("RenameGroup: %v", err)
+	}
+	name, ok := r.GroupName(label)
+	if !ok || name != "Alice" {
+		t.Fatalf("GroupName = %q, %v; want %q, true", name, ok, "Alice")
+	}
+}
+
+func TestGroupIndexGrowToAssignsFreshLabels(t *testing.T) {
+	g := newGroupIndex([]GroupID{0, 1}, defaultGroupEdgeThreshold)
+
+	g.growTo(4)
+
+	if len(g.labels) != 4 {
+		t.Fatalf("labels = %v, want 4 entries", g.labels)
+	}
+	if g.labels[2] == g.labels[0] || g.labels[2] == g.labels[1] || g.labels[3] == g.labels[2] {
+		t.Fatalf("growTo gave new indexes colliding labels: %v", g.labels)
+	}
+
+	// Already long enough: growTo must be a no-op, not truncate or
+	// reassign existing labels.
+	before := append([]GroupID(nil), g.labels...)
+	g.growTo(2)
+	if len(g.labels) != len(before) {
+		t.Fatalf("growTo(2) changed length: got %v, want %v", g.labels, before)
+	}
+	for i := range before {
+		if g.labels[i] != before[i] {
+			t.Fatalf("growTo(2) reassigned labels: got %v, want %v", g.labels, before)
+		}
+	}
+}
+
+// TestClusterDatasetConcurrentGrowthLabelsEveryEntry guards against the
+// race where ClusterDataset reads Dataset, runs Chinese Whispers
+// unlocked, and only then installs groups: a concurrent AddSingleData
+// landing in that window has its onDatasetGrew see groups still nil (so
+// it no-ops), and ClusterDataset must not then install labels sized to
+// the stale pre-growth Dataset length - every entry, old or new, must
+// come out of this labeled regardless of how the two calls interleave.
+func TestClusterDatasetConcurrentGrowthLabelsEveryEntry(t *testing.T) {
+	r := newTestRecognizer(0.0, 0.01, 5.0, 5.01)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := r.ClusterDataset(); err != nil {
+			t.Errorf("ClusterDataset: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := r.AddSingleData(Data{Id: "new", Descriptor: descriptorAt(10.0)}); err != nil {
+			t.Errorf("AddSingleData: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	for i := range r.Dataset {
+		if _, ok := r.groupForIndex(i); !ok {
+			t.Fatalf("entry %d (%s) has no group label after concurrent ClusterDataset/AddSingleData", i, r.Dataset[i].Id)
+		}
+	}
+}
+
+// TestGroupIndexRemoveIndexShiftsLabels exercises the same label-shifting
+// groupIndex.removeIndex does for RemoveFromDataset, without going
+// through RemoveFromDataset itself: that method also calls SetSamples,
+// which needs a live goFace.Recognizer backed by real model files that
+// aren't available in this package's tests.
+func TestGroupIndexRemoveIndexShiftsLabels(t *testing.T) {
+	g := newGroupIndex([]GroupID{10, 11, 12, 13}, defaultGroupEdgeThreshold)
+
+	g.removeIndex(0)
+
+	want := []GroupID{11, 12, 13}
+	if len(g.labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", g.labels, want)
+	}
+	for i, label := range want {
+		if g.labels[i] != label {
+			t.Fatalf("labels = %v, want %v", g.labels, want)
+		}
+	}
+}
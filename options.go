@@ -0,0 +1,160 @@
+package recognizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	goFace "github.com/Kagami/go-face"
+)
+
+// Option configures a Recognizer built with NewRecognizer.
+type Option func(*recognizerConfig)
+
+// recognizerConfig collects everything an Option can set before the
+// underlying goFace.Recognizer is constructed. go-face itself only takes
+// a single model directory (expecting shapeModelFile, descriptorModelFile
+// and cnnModelFile inside it, see stageModelDir) plus size/padding/
+// jittering via NewRecognizerWithConfig, so per-model overrides are
+// implemented by staging those files under their expected names rather
+// than by calling setters go-face doesn't have.
+type recognizerConfig struct {
+	cnnModel        string
+	shapeModel      string
+	descriptorModel string
+	size            int
+	padding         float32
+	jittering       int
+	minImageSize    int
+}
+
+// The fixed filenames goFace.NewRecognizer expects inside its model
+// directory. See https://github.com/Kagami/go-face-testdata.
+const (
+	shapeModelFile      = "shape_predictor_5_face_landmarks.dat"
+	descriptorModelFile = "dlib_face_recognition_resnet_model_v1.dat"
+	cnnModelFile        = "mmod_human_face_detector.dat"
+)
+
+// defaultSize, defaultPadding and defaultJittering mirror the defaults
+// go-face itself uses in NewRecognizer before any NewRecognizerWithConfig
+// override.
+const (
+	defaultSize      = 150
+	defaultPadding   = 0.25
+	defaultJittering = 0
+)
+
+// WithCNNModel overrides the CNN face detector model go-face would
+// otherwise load from the model directory (mmod_human_face_detector.dat).
+func WithCNNModel(path string) Option {
+	return func(c *recognizerConfig) { c.cnnModel = path }
+}
+
+// WithShapeModel overrides the shape predictor model go-face would
+// otherwise load from the model directory (shape_predictor_5_face_landmarks.dat).
+func WithShapeModel(path string) Option {
+	return func(c *recognizerConfig) { c.shapeModel = path }
+}
+
+// WithDescriptorModel overrides the face descriptor model go-face would
+// otherwise load from the model directory (dlib_face_recognition_resnet_model_v1.dat).
+func WithDescriptorModel(path string) Option {
+	return func(c *recognizerConfig) { c.descriptorModel = path }
+}
+
+// WithSize sets the size, in pixels, faces are resized to before
+// descriptor extraction.
+func WithSize(size int) Option {
+	return func(c *recognizerConfig) { c.size = size }
+}
+
+// WithPadding sets the fraction of padding added around a detected face
+// before descriptor extraction.
+func WithPadding(padding float32) Option {
+	return func(c *recognizerConfig) { c.padding = padding }
+}
+
+// WithMinImageSize rejects images smaller than size pixels in either
+// dimension before they reach the detector. go-face has no native
+// concept of this, so it's enforced by the Recognizer itself (see
+// checkMinImageSize).
+func WithMinImageSize(size int) Option {
+	return func(c *recognizerConfig) { c.minImageSize = size }
+}
+
+// WithJittering sets how many jittered samples are averaged when
+// computing a face descriptor. Higher values are more accurate and
+// slower.
+func WithJittering(n int) Option {
+	return func(c *recognizerConfig) { c.jittering = n }
+}
+
+// NewRecognizer builds a Recognizer from a model directory plus any
+// Option overrides. Existing callers that only need the defaults can
+// keep using Init.
+func NewRecognizer(modelDir string, opts ...Option) (*Recognizer, error) {
+
+	cfg := recognizerConfig{size: defaultSize, padding: defaultPadding, jittering: defaultJittering}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	dir, cleanup, err := stageModelDir(modelDir, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	rec, err := goFace.NewRecognizerWithConfig(dir, cfg.size, cfg.padding, cfg.jittering)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recognizer{
+		Tolerance:    0.4,
+		UseCNN:       false,
+		UseGray:      true,
+		Dataset:      make([]Data, 0),
+		rec:          rec,
+		minImageSize: cfg.minImageSize,
+	}, nil
+}
+
+// stageModelDir returns modelDir unchanged (and a no-op cleanup) if none
+// of the per-model Options were set. Otherwise it symlinks each of
+// shapeModelFile/descriptorModelFile/cnnModelFile to its override if one
+// was given, falling back to modelDir's own copy, inside a scratch
+// directory - since go-face only ever reads those three fixed names out
+// of a single directory, this is how individual model paths get threaded
+// through to it.
+func stageModelDir(modelDir string, cfg recognizerConfig) (dir string, cleanup func(), err error) {
+	noop := func() {}
+
+	if cfg.cnnModel == "" && cfg.shapeModel == "" && cfg.descriptorModel == "" {
+		return modelDir, noop, nil
+	}
+
+	dir, err = os.MkdirTemp("", "go-recognizer-models-*")
+	if err != nil {
+		return "", noop, err
+	}
+
+	overrides := map[string]string{
+		shapeModelFile:      cfg.shapeModel,
+		descriptorModelFile: cfg.descriptorModel,
+		cnnModelFile:        cfg.cnnModel,
+	}
+	for name, override := range overrides {
+		src := override
+		if src == "" {
+			src = filepath.Join(modelDir, name)
+		}
+		if err := os.Symlink(src, filepath.Join(dir, name)); err != nil {
+			os.RemoveAll(dir)
+			return "", noop, fmt.Errorf("can't stage model %s: %v", name, err)
+		}
+	}
+
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
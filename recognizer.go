@@ -6,8 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/jpeg"
+	"io"
 	"os"
+	"path/filepath"
+	"sync"
 
 	goFace "github.com/Kagami/go-face"
 )
@@ -24,6 +28,14 @@ type Face struct {
 	Rectangle image.Rectangle
 }
 
+// ClassifyResult pairs the outcome of a single classification with the
+// path that produced it, as returned by ClassifyBatch.
+type ClassifyResult struct {
+	Path  string
+	Faces []Face
+	Err   error
+}
+
 /*
 A Recognizer creates face descriptors for provided images and
 classifies them into categories.
@@ -34,6 +46,23 @@ type Recognizer struct {
 	UseCNN    bool
 	UseGray   bool
 	Dataset   []Data
+
+	// minImageSize is the minimum width/height, in pixels, an image must
+	// have to be recognized. go-face has no native equivalent, so it's
+	// enforced here by checkMinImageSize before a call ever reaches rec.
+	minImageSize int
+
+	// mu guards Dataset and everything derived from it (samples loaded
+	// into rec, group assignments), and every call into rec: SetSamples
+	// rebuilds rec's sample table, and a Classify*/Recognize* reading
+	// Dataset by rec-assigned index must see a consistent pairing of the
+	// two, so both sides take mu rather than relying on rec's own
+	// internal locking alone.
+	mu sync.RWMutex
+
+	store Store
+
+	groups *groupIndex
 }
 
 /*
@@ -67,6 +96,62 @@ func (_this *Recognizer) Close() {
 
 }
 
+// createTempGrayFile decodes the image at path, converts it to
+// grayscale and writes it to a new temp file, returning its path.
+// Recognition runs against the grayscale copy when UseGray is set,
+// which is the default. id is accepted for parity with callers that
+// have a natural id on hand (e.g. a dataset entry); uniqueness of the
+// returned path comes from os.CreateTemp, not from id.
+func (_this *Recognizer) createTempGrayFile(path string, id string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", err
+	}
+
+	gray := image.NewGray(img.Bounds())
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	out, err := os.CreateTemp("", "go-recognizer-gray-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if err := jpeg.Encode(out, gray, nil); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+
+	return out.Name(), nil
+}
+
+// grayEncodeBytes decodes jpegBytes, converts the result to grayscale and
+// re-encodes it into a fresh in-memory buffer. It's the byte-path
+// equivalent of createTempGrayFile, used by RecognizeSingleBytes and
+// RecognizeBytes so the gray conversion never touches disk.
+func grayEncodeBytes(jpegBytes []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(jpegBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	gray := image.NewGray(img.Bounds())
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, gray, nil); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 func (_this *Recognizer) addImageToDatasetAndReturnFaceData(path string, id string) (*Data, error) {
 	file := path
 	var err error
@@ -85,11 +170,13 @@ func (_this *Recognizer) addImageToDatasetAndReturnFaceData(path string, id stri
 
 	var faces []goFace.Face
 
+	_this.mu.RLock()
 	if _this.UseCNN {
 		faces, err = _this.rec.RecognizeFileCNN(file)
 	} else {
 		faces, err = _this.rec.RecognizeFile(file)
 	}
+	_this.mu.RUnlock()
 
 	if err != nil {
 		return nil, err
@@ -107,7 +194,18 @@ func (_this *Recognizer) addImageToDatasetAndReturnFaceData(path string, id stri
 	f.Id = id
 	f.Descriptor = faces[0].Descriptor
 
+	_this.mu.Lock()
 	_this.Dataset = append(_this.Dataset, f)
+	newIndex := len(_this.Dataset) - 1
+	_this.mu.Unlock()
+
+	if _this.store != nil {
+		if err := _this.store.Upsert(f); err != nil {
+			return nil, fmt.Errorf("can't persist dataset entry: %v", err)
+		}
+	}
+
+	_this.onDatasetGrew(newIndex)
 
 	return &f, nil
 }
@@ -124,15 +222,11 @@ func (_this *Recognizer) AddImageToDataset(path string, Id string) error {
 AddRawImageToDataset addd a sample golang image to the dataset
 */
 func (_this *Recognizer) AddRawImageToDataset(img image.Image, id string) (*Data, error) {
-	tmpFile := os.TempDir() + "/" + "123e4567-e89b-12d3-a456-426614174000.jpg"
-	f, err := os.Create(tmpFile)
+	tmpFile, err := writeTempJPEG(img)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	if err = jpeg.Encode(f, img, nil); err != nil {
-		return nil, err
-	}
+	defer os.Remove(tmpFile)
 
 	return _this.addImageToDatasetAndReturnFaceData(tmpFile, id)
 }
@@ -141,29 +235,71 @@ func (_this *Recognizer) AddRawImageToDataset(img image.Image, id string) (*Data
 AddImageBytesToDataset addd a sample golang image to the dataset
 */
 func (_this *Recognizer) AddImageBytesToDataset(imgBytes []byte, id string) (*Data, error) {
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	tmpFile, err := writeTempBytes(imgBytes)
 	if err != nil {
 		return nil, err
 	}
+	defer os.Remove(tmpFile)
 
-	return _this.AddRawImageToDataset(img, id)
+	return _this.addImageToDatasetAndReturnFaceData(tmpFile, id)
 }
 
 /*
-AddSingleData adds a single data to the dataset
+AddSingleData adds a single data to the dataset. Returns an error if the
+entry couldn't be written through to the attached Store, if any; Dataset
+itself has already been updated by the time that happens.
 */
-func (_this *Recognizer) AddSingleData(d Data) {
+func (_this *Recognizer) AddSingleData(d Data) error {
+	_this.mu.Lock()
 	_this.Dataset = append(_this.Dataset, d)
+	newIndex := len(_this.Dataset) - 1
+	_this.mu.Unlock()
+
+	if _this.store != nil {
+		if err := _this.store.Upsert(d); err != nil {
+			return fmt.Errorf("can't persist dataset entry: %v", err)
+		}
+	}
+
+	_this.onDatasetGrew(newIndex)
+
+	return nil
 }
 
 /*
-AddMultipleData adds a single data to the dataset
+AddMultipleData adds multiple data to the dataset. Returns the first
+Store write error encountered, if any, after attempting every entry.
 */
-func (_this *Recognizer) AddMultipleData(datas []Data) {
+func (_this *Recognizer) AddMultipleData(datas []Data) error {
+	_this.mu.Lock()
+	firstIndex := len(_this.Dataset)
 	_this.Dataset = append(_this.Dataset, datas...)
+	_this.mu.Unlock()
+
+	if _this.store != nil {
+		var firstErr error
+		for _, d := range datas {
+			if err := _this.store.Upsert(d); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("can't persist dataset entry: %v", err)
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+	}
+
+	for i := range datas {
+		_this.onDatasetGrew(firstIndex + i)
+	}
+
+	return nil
 }
 
-func (_this *Recognizer) RemoveFromDataset(id string) {
+// RemoveFromDataset removes the dataset entry with the given id, if any,
+// and returns an error if the attached Store, if any, failed to persist
+// the removal.
+func (_this *Recognizer) RemoveFromDataset(id string) error {
+	_this.mu.Lock()
 	index := -1
 	for i, f := range _this.Dataset {
 		if f.Id == id {
@@ -173,10 +309,24 @@ func (_this *Recognizer) RemoveFromDataset(id string) {
 	}
 
 	if index == -1 {
-		return
+		_this.mu.Unlock()
+		return nil
 	}
 	_this.Dataset = append(_this.Dataset[:index], _this.Dataset[index+1:]...)
+	if _this.groups != nil {
+		_this.groups.removeIndex(index)
+	}
+	_this.mu.Unlock()
+
 	_this.SetSamples()
+
+	if _this.store != nil {
+		if err := _this.store.Delete(id); err != nil {
+			return fmt.Errorf("can't persist dataset removal: %v", err)
+		}
+	}
+
+	return nil
 }
 
 /*
@@ -184,6 +334,9 @@ SetSamples sets known descriptors so you can classify the new ones.
 */
 func (_this *Recognizer) SetSamples() {
 
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
 	var samples []goFace.Descriptor
 	var avengers []int32
 
@@ -202,12 +355,21 @@ Only JPEG format is currently supported.
 */
 func (_this *Recognizer) RecognizeSingle(path string) (goFace.Face, error) {
 
+	if err := _this.checkMinImageSize(path); err != nil {
+		return goFace.Face{}, err
+	}
+
 	file := path
 	var err error
 
 	if _this.UseGray {
 
-		file, err = _this.createTempGrayFile(file, "64ab59ac42d69274f06eadb11348969e")
+		grayID, err2 := uniqueTempID()
+		if err2 != nil {
+			return goFace.Face{}, err2
+		}
+
+		file, err = _this.createTempGrayFile(file, grayID)
 
 		if err != nil {
 			return goFace.Face{}, err
@@ -219,11 +381,13 @@ func (_this *Recognizer) RecognizeSingle(path string) (goFace.Face, error) {
 
 	var idFace *goFace.Face
 
+	_this.mu.RLock()
 	if _this.UseCNN {
 		idFace, err = _this.rec.RecognizeSingleFileCNN(file)
 	} else {
 		idFace, err = _this.rec.RecognizeSingleFile(file)
 	}
+	_this.mu.RUnlock()
 
 	if err != nil {
 		return goFace.Face{}, fmt.Errorf("can't recognize: %v", err)
@@ -237,6 +401,49 @@ func (_this *Recognizer) RecognizeSingle(path string) (goFace.Face, error) {
 
 }
 
+/*
+RecognizeSingleBytes is the in-memory equivalent of RecognizeSingle: it
+never touches disk. go-face's Recognizer.RecognizeSingle/
+RecognizeSingleCNN decode JPEG bytes natively, so the non-gray path hands
+jpegBytes to them directly; the gray path re-encodes a grayscale copy
+into memory via grayEncodeBytes first.
+*/
+func (_this *Recognizer) RecognizeSingleBytes(jpegBytes []byte) (goFace.Face, error) {
+
+	if err := _this.checkMinImageSizeBytes(jpegBytes); err != nil {
+		return goFace.Face{}, err
+	}
+
+	data := jpegBytes
+	if _this.UseGray {
+		gray, err := grayEncodeBytes(jpegBytes)
+		if err != nil {
+			return goFace.Face{}, err
+		}
+		data = gray
+	}
+
+	var idFace *goFace.Face
+	var err error
+
+	_this.mu.RLock()
+	if _this.UseCNN {
+		idFace, err = _this.rec.RecognizeSingleCNN(data)
+	} else {
+		idFace, err = _this.rec.RecognizeSingle(data)
+	}
+	_this.mu.RUnlock()
+
+	if err != nil {
+		return goFace.Face{}, fmt.Errorf("can't recognize: %v", err)
+	}
+	if idFace == nil {
+		return goFace.Face{}, fmt.Errorf("not a single face on the image")
+	}
+
+	return *idFace, nil
+}
+
 /*
 RecognizeMultiples returns all faces found on the provided image, sorted from
 left to right. Empty list is returned if there are no faces, error is
@@ -245,12 +452,21 @@ Only JPEG format is currently supported.
 */
 func (_this *Recognizer) RecognizeMultiples(path string) ([]goFace.Face, error) {
 
+	if err := _this.checkMinImageSize(path); err != nil {
+		return nil, err
+	}
+
 	file := path
 	var err error
 
 	if _this.UseGray {
 
-		file, err = _this.createTempGrayFile(file, "64ab59ac42d69274f06eadb11348969e")
+		grayID, err2 := uniqueTempID()
+		if err2 != nil {
+			return nil, err2
+		}
+
+		file, err = _this.createTempGrayFile(file, grayID)
 
 		if err != nil {
 			return nil, err
@@ -262,11 +478,13 @@ func (_this *Recognizer) RecognizeMultiples(path string) ([]goFace.Face, error)
 
 	var idFaces []goFace.Face
 
+	_this.mu.RLock()
 	if _this.UseCNN {
 		idFaces, err = _this.rec.RecognizeFileCNN(file)
 	} else {
 		idFaces, err = _this.rec.RecognizeFile(file)
 	}
+	_this.mu.RUnlock()
 
 	if err != nil {
 		return nil, fmt.Errorf("can't recognize: %v", err)
@@ -277,18 +495,67 @@ func (_this *Recognizer) RecognizeMultiples(path string) ([]goFace.Face, error)
 }
 
 func (_this *Recognizer) RecognizeMultiplesFromImage(img image.Image) ([]goFace.Face, error) {
-	uuid := "4209db13-5ac1-448c-8774-0c8ec51696a8"
-	tmpFile := os.TempDir() + "/" + uuid + ".jpg"
-	f, err := os.Create(tmpFile)
+	tmpFile, err := writeTempJPEG(img)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	if err = jpeg.Encode(f, img, nil); err != nil {
+	defer os.Remove(tmpFile)
+
+	return _this.RecognizeMultiples(tmpFile)
+}
+
+/*
+RecognizeBytes is the in-memory equivalent of RecognizeMultiples: it
+never touches disk. go-face's Recognizer.Recognize/RecognizeCNN decode
+JPEG bytes natively in C++, so the non-gray path hands jpegBytes to them
+directly; the gray path re-encodes a grayscale copy into memory via
+grayEncodeBytes first.
+*/
+func (_this *Recognizer) RecognizeBytes(jpegBytes []byte) ([]goFace.Face, error) {
+
+	if err := _this.checkMinImageSizeBytes(jpegBytes); err != nil {
 		return nil, err
 	}
 
-	return _this.RecognizeMultiples(tmpFile)
+	data := jpegBytes
+	if _this.UseGray {
+		gray, err := grayEncodeBytes(jpegBytes)
+		if err != nil {
+			return nil, err
+		}
+		data = gray
+	}
+
+	var idFaces []goFace.Face
+	var err error
+
+	_this.mu.RLock()
+	if _this.UseCNN {
+		idFaces, err = _this.rec.RecognizeCNN(data)
+	} else {
+		idFaces, err = _this.rec.Recognize(data)
+	}
+	_this.mu.RUnlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("can't recognize: %v", err)
+	}
+
+	return idFaces, nil
+}
+
+/*
+RecognizeImage recognizes faces directly from a decoded image.Image,
+encoding it into an in-memory buffer once instead of round-tripping
+through a caller-visible temp file.
+*/
+func (_this *Recognizer) RecognizeImage(img image.Image) ([]goFace.Face, error) {
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, nil); err != nil {
+		return nil, err
+	}
+
+	return _this.RecognizeBytes(buf.Bytes())
 }
 
 /*
@@ -302,40 +569,46 @@ func (_this *Recognizer) Classify(path string) ([]Face, error) {
 		return nil, err
 	}
 
+	return _this.classifyFace(face)
+
+}
+
+func (_this *Recognizer) classifyFace(face goFace.Face) ([]Face, error) {
+
+	_this.mu.RLock()
 	personID := _this.rec.ClassifyThreshold(face.Descriptor, _this.Tolerance)
 	if personID < 0 {
+		_this.mu.RUnlock()
 		return nil, fmt.Errorf("can't classify")
 	}
-
-	facesRec := make([]Face, 0)
 	aux := Face{Data: _this.Dataset[personID], Rectangle: face.Rectangle}
+	_this.mu.RUnlock()
+
+	facesRec := make([]Face, 0, 1)
 	facesRec = append(facesRec, aux)
 
 	return facesRec, nil
-
 }
 
 func (_this *Recognizer) ClassifyWithImage(img image.Image) ([]Face, error) {
-	tmpFile := os.TempDir() + "/" + "72c94a8e-a2fd-4fca-8869-ae957ba2e04a.jpg"
-	f, err := os.Create(tmpFile)
+	face, err := _this.RecognizeImage(img)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("can't recognize: %v", err)
 	}
-	defer f.Close()
-	if err = jpeg.Encode(f, img, nil); err != nil {
-		return nil, err
+	if len(face) != 1 {
+		return nil, fmt.Errorf("not a single face on the image")
 	}
 
-	return _this.Classify(tmpFile)
+	return _this.classifyFace(face[0])
 }
 
 func (_this *Recognizer) ClassifyWithBytes(imgBytes []byte) ([]Face, error) {
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	face, err := _this.RecognizeSingleBytes(imgBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	return _this.ClassifyWithImage(img)
+	return _this.classifyFace(face)
 }
 
 /*
@@ -349,6 +622,15 @@ func (_this *Recognizer) ClassifyMultiples(path string) ([]Face, error) {
 		return nil, fmt.Errorf("can't recognize: %v", err)
 	}
 
+	return _this.classifyFaces(faces), nil
+
+}
+
+func (_this *Recognizer) classifyFaces(faces []goFace.Face) []Face {
+
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+
 	facesRec := make([]Face, 0)
 
 	for _, f := range faces {
@@ -364,31 +646,63 @@ func (_this *Recognizer) ClassifyMultiples(path string) ([]Face, error) {
 
 	}
 
-	return facesRec, nil
-
+	return facesRec
 }
 
 func (_this *Recognizer) ClassifyMultiplesWithImage(img image.Image) ([]Face, error) {
-	tmpFile := os.TempDir() + "/" + "72c94a8e-a2fd-4fca-8869-ae957ba2e04a.jpg"
-	f, err := os.Create(tmpFile)
+	faces, err := _this.RecognizeImage(img)
 	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	if err = jpeg.Encode(f, img, nil); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("can't recognize: %v", err)
 	}
 
-	return _this.ClassifyMultiples(tmpFile)
+	return _this.classifyFaces(faces), nil
 }
 
 func (_this *Recognizer) ClassifyMultiplesWithBytes(imgBytes []byte) ([]Face, error) {
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
+	faces, err := _this.RecognizeBytes(imgBytes)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("can't recognize: %v", err)
 	}
 
-	return _this.ClassifyMultiplesWithImage(img)
+	return _this.classifyFaces(faces), nil
+}
+
+/*
+ClassifyBatch runs ClassifyMultiples over paths concurrently, using a
+worker pool of the given size (a concurrency <= 0 falls back to 1). It's
+meant for server-side pipelines that need to scan thousands of images
+without processing them one at a time; Recognizer is safe to share across
+the workers.
+*/
+func (_this *Recognizer) ClassifyBatch(paths []string, concurrency int) []ClassifyResult {
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]ClassifyResult, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				faces, err := _this.ClassifyMultiples(paths[i])
+				results[i] = ClassifyResult{Path: paths[i], Faces: faces, Err: err}
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	return results
 }
 
 /*
@@ -399,6 +713,49 @@ func fileExists(FileName string) bool {
 	return (err == nil) && !file.IsDir()
 }
 
+// checkMinImageSize rejects images smaller than minImageSize in either
+// dimension before they reach the detector. It reads only the image
+// header (via image.DecodeConfig), not the full pixel data, so it's
+// cheap even for large images. A zero minImageSize (the default) skips
+// the check entirely.
+func (_this *Recognizer) checkMinImageSize(path string) error {
+	if _this.minImageSize <= 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return _this.checkMinImageSizeReader(f)
+}
+
+// checkMinImageSizeBytes is checkMinImageSize's in-memory equivalent, for
+// the RecognizeSingleBytes/RecognizeBytes entry points that never touch
+// disk.
+func (_this *Recognizer) checkMinImageSizeBytes(b []byte) error {
+	if _this.minImageSize <= 0 {
+		return nil
+	}
+
+	return _this.checkMinImageSizeReader(bytes.NewReader(b))
+}
+
+func (_this *Recognizer) checkMinImageSizeReader(r io.Reader) error {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("can't read image dimensions: %v", err)
+	}
+
+	if cfg.Width < _this.minImageSize || cfg.Height < _this.minImageSize {
+		return fmt.Errorf("image %dx%d is smaller than the configured minimum of %d", cfg.Width, cfg.Height, _this.minImageSize)
+	}
+
+	return nil
+}
+
 /*
 jsonMarshal Marshal interface to array of byte
 */
@@ -409,3 +766,53 @@ func jsonMarshal(t interface{}) ([]byte, error) {
 	err := encoder.Encode(t)
 	return buffer.Bytes(), err
 }
+
+// writeTempJPEG encodes img into a uniquely named temp file and returns
+// its path. Each call gets its own file (via os.CreateTemp) so concurrent
+// callers never collide, unlike the old hardcoded UUID names.
+func writeTempJPEG(img image.Image) (string, error) {
+	f, err := os.CreateTemp("", "go-recognizer-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := jpeg.Encode(f, img, nil); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// writeTempBytes writes raw (already-encoded) image bytes to a uniquely
+// named temp file and returns its path.
+func writeTempBytes(b []byte) (string, error) {
+	f, err := os.CreateTemp("", "go-recognizer-*.jpg")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// uniqueTempID returns a filesystem-safe string unique to this call.
+// RecognizeSingle and RecognizeMultiples pass it to createTempGrayFile as
+// the id; the gray-converted scratch file's own path comes from
+// os.CreateTemp inside createTempGrayFile, which does not use the id.
+func uniqueTempID() (string, error) {
+	f, err := os.CreateTemp("", "go-recognizer-gray-*")
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(f.Name())
+	f.Close()
+	os.Remove(f.Name())
+	return name, nil
+}
@@ -0,0 +1,80 @@
+package recognizer
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"bytes"
+)
+
+// benchImage is a representative frame size for the benchmarks below -
+// large enough that disk I/O cost isn't swamped by file-open/close
+// overhead.
+func benchImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func benchImageBytes() []byte {
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, benchImage(), nil); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+/*
+BenchmarkRecognizeBytesOldGrayPath and BenchmarkRecognizeBytesNewGrayPath
+cover what RecognizeBytes/RecognizeSingleBytes actually do under UseGray
+(the default), end to end up to the point data is handed to the native
+recognizer - the native call itself needs a real dlib-backed Recognizer,
+which isn't something any benchmark can construct without model files on
+disk, so it's out of scope for both variants here.
+
+The old variant reproduces RecognizeBytes's previous implementation:
+write the raw JPEG to a temp file, then createTempGrayFile opens it,
+decodes, converts to gray and writes a second temp file - two disk
+writes and a disk read before the (since-removed) file-based native call
+would have read the result back a third time. The new variant is
+grayEncodeBytes, the in-memory replacement: one decode, one re-encode,
+zero disk I/O. Benchmarking only the isolated jpeg.Encode step (as an
+earlier version of this file did) missed this entirely, since that step
+was never what RecognizeBytes actually spent its time on by default.
+*/
+func BenchmarkRecognizeBytesOldGrayPath(b *testing.B) {
+	raw := benchImageBytes()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tmpFile, err := writeTempBytes(raw)
+		if err != nil {
+			b.Fatalf("writeTempBytes: %v", err)
+		}
+
+		grayFile, err := (&Recognizer{}).createTempGrayFile(tmpFile, "bench")
+		os.Remove(tmpFile)
+		if err != nil {
+			b.Fatalf("createTempGrayFile: %v", err)
+		}
+		os.Remove(grayFile)
+	}
+}
+
+func BenchmarkRecognizeBytesNewGrayPath(b *testing.B) {
+	raw := benchImageBytes()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := grayEncodeBytes(raw); err != nil {
+			b.Fatalf("grayEncodeBytes: %v", err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package recognizer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Store persists a Recognizer's Dataset so applications don't have to
+// re-enroll every face on each startup.
+type Store interface {
+	Load() ([]Data, error)
+	Save([]Data) error
+	Upsert(Data) error
+	Delete(id string) error
+}
+
+/*
+WithStore attaches a Store to the recognizer. Once set, AddImageToDataset,
+AddSingleData, AddMultipleData and RemoveFromDataset write through to it;
+call LoadFromStore to hydrate Dataset from it on startup.
+*/
+func (_this *Recognizer) WithStore(s Store) *Recognizer {
+	_this.store = s
+	return _this
+}
+
+/*
+LoadFromStore replaces Dataset with whatever the attached Store holds and
+calls SetSamples so the recognizer is immediately ready to classify.
+*/
+func (_this *Recognizer) LoadFromStore() error {
+	if _this.store == nil {
+		return errors.New("recognizer has no store, call WithStore first")
+	}
+
+	data, err := _this.store.Load()
+	if err != nil {
+		return err
+	}
+
+	_this.mu.Lock()
+	_this.Dataset = data
+	_this.mu.Unlock()
+
+	_this.SetSamples()
+
+	return nil
+}
+
+// MemoryStore is an in-memory Store, mainly useful for tests or for
+// running a Recognizer without persistence while still going through the
+// Store code path.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string]Data
+}
+
+// NewMemoryStore returns a ready-to-use MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string]Data)}
+}
+
+func (_this *MemoryStore) Load() ([]Data, error) {
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+
+	out := make([]Data, 0, len(_this.data))
+	for _, d := range _this.data {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (_this *MemoryStore) Save(data []Data) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	_this.data = make(map[string]Data, len(data))
+	for _, d := range data {
+		_this.data[d.Id] = d
+	}
+	return nil
+}
+
+func (_this *MemoryStore) Upsert(d Data) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	_this.data[d.Id] = d
+	return nil
+}
+
+func (_this *MemoryStore) Delete(id string) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	delete(_this.data, id)
+	return nil
+}
+
+// JSONStore is a Store backed by a single JSON file on disk, reusing the
+// package's existing jsonMarshal helper for encoding.
+type JSONStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONStore returns a Store that reads from and writes to path. The
+// file is created on the first Save/Upsert if it doesn't already exist.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{path: path}
+}
+
+func (_this *JSONStore) Load() ([]Data, error) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	return _this.loadLocked()
+}
+
+func (_this *JSONStore) Save(data []Data) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	return _this.saveLocked(data)
+}
+
+func (_this *JSONStore) Upsert(d Data) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	data, err := _this.loadLocked()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, existing := range data {
+		if existing.Id == d.Id {
+			data[i] = d
+			found = true
+			break
+		}
+	}
+	if !found {
+		data = append(data, d)
+	}
+
+	return _this.saveLocked(data)
+}
+
+func (_this *JSONStore) Delete(id string) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	data, err := _this.loadLocked()
+	if err != nil {
+		return err
+	}
+
+	for i, existing := range data {
+		if existing.Id == id {
+			data = append(data[:i], data[i+1:]...)
+			break
+		}
+	}
+
+	return _this.saveLocked(data)
+}
+
+// loadLocked/saveLocked read and write the file without taking mu, so
+// Upsert/Delete can hold a single lock across their whole
+// read-modify-write and Load/Save can wrap them for the exported,
+// standalone-locking API.
+func (_this *JSONStore) loadLocked() ([]Data, error) {
+	if !fileExists(_this.path) {
+		return []Data{}, nil
+	}
+
+	raw, err := os.ReadFile(_this.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (_this *JSONStore) saveLocked(data []Data) error {
+	raw, err := jsonMarshal(data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(_this.path, raw, 0o644)
+}
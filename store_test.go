@@ -0,0 +1,129 @@
+package recognizer
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func testData(id string, v float32) Data {
+	return Data{Id: id, Descriptor: descriptorAt(v)}
+}
+
+func runStoreContract(t *testing.T, newStore func() Store) {
+	t.Run("UpsertThenLoad", func(t *testing.T) {
+		s := newStore()
+		if err := s.Upsert(testData("alice", 1)); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		data, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(data) != 1 || data[0].Id != "alice" {
+			t.Fatalf("Load = %v, want one entry for alice", data)
+		}
+	})
+
+	t.Run("UpsertOverwritesExisting", func(t *testing.T) {
+		s := newStore()
+		if err := s.Upsert(testData("alice", 1)); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		if err := s.Upsert(testData("alice", 2)); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		data, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(data) != 1 {
+			t.Fatalf("Load = %v, want exactly one entry after overwrite", data)
+		}
+		if data[0].Descriptor != descriptorAt(2) {
+			t.Fatalf("Upsert didn't overwrite the existing descriptor")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStore()
+		if err := s.Upsert(testData("alice", 1)); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		if err := s.Upsert(testData("bob", 2)); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		if err := s.Delete("alice"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		data, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(data) != 1 || data[0].Id != "bob" {
+			t.Fatalf("Load = %v, want only bob left", data)
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		s := newStore()
+		if err := s.Save([]Data{testData("alice", 1), testData("bob", 2)}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		data, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(data) != 2 {
+			t.Fatalf("Load = %v, want 2 entries", data)
+		}
+	})
+
+	t.Run("ConcurrentUpsertsAllSurvive", func(t *testing.T) {
+		s := newStore()
+		const n = 20
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_ = s.Upsert(testData(string(rune('a'+i)), float32(i)))
+			}(i)
+		}
+		wg.Wait()
+
+		data, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(data) != n {
+			t.Fatalf("Load returned %d entries, want %d (concurrent Upserts lost a write)", len(data), n)
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	runStoreContract(t, func() Store { return NewMemoryStore() })
+}
+
+func TestJSONStore(t *testing.T) {
+	runStoreContract(t, func() Store { return NewJSONStore(filepath.Join(t.TempDir(), "dataset.json")) })
+}
+
+func TestJSONStoreLoadMissingFileReturnsEmpty(t *testing.T) {
+	s := NewJSONStore(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	data, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("Load = %v, want empty", data)
+	}
+}
+
+func TestLoadFromStoreRequiresStore(t *testing.T) {
+	r := &Recognizer{}
+	if err := r.LoadFromStore(); err == nil {
+		t.Fatalf("expected LoadFromStore to fail without WithStore")
+	}
+}